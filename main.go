@@ -13,14 +13,20 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html"
+	"io"
 	"log"
+	"math/rand"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
 	"os"
 	"regexp"
+	"strconv"
 	str "strings"
+	"sync"
 	"time"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -30,21 +36,29 @@ import (
 	"github.com/spf13/pflag"
 )
 
-// Structure storing the script parameters
+// Structure storing the script parameters shared by every watched project
 type Settings struct {
-	url       string                    // Project description URL
-	interval  time.Duration             // Interval between polling
-	quiet     bool                      // Quiet mode
-	watch     map[int]*Reward           // Map of rewards to watch, indexed by their ID
-	notifiers []*notifications.Notifier // Slice of all the available notifiers
+	interval             time.Duration // Interval between polling, jittered per project
+	quiet                bool          // Quiet mode
+	maxConcurrentFetches int           // Maximum number of project pages fetched at the same time
+	maxRetries           int           // Maximum number of retries after a transient failure before giving up on a project until the next poll
+	backoffMax           time.Duration // Upper bound on the exponential backoff delay between retries
+	backoffFactor        float64       // Multiplier applied to the backoff delay after each failed attempt
 }
 
-// Structure storing the project details
+// Structure storing the details about a single watched project
 type Project struct {
+	url             string          // Project description URL
+	client          *http.Client    // HTTP client used for every request to this project, with its own cookie jar
+	etag            string          // ETag of the last successful response, sent back as If-None-Match
+	lastModified    string          // Last-Modified of the last successful response, sent back as If-Modified-Since
 	name            string          // Project name
 	rewards         map[int]*Reward // Map of all limited rewards, indexed by their ID
 	currency_symbol string          // The symbol representing the project currency
 	initialized     bool            // Whether that project immutable data has already been obtained
+	watch           map[int]*Reward // Map of rewards to watch, indexed by their ID
+	explicitRewards []int           // Rewards selected for this project via "URL:price1,price2"
+	mu              sync.Mutex      // Guards rewards and watch, read/written by its own goroutine and the Telegram bot
 }
 
 // Structure storing the details about a specific reward
@@ -60,23 +74,61 @@ type Reward struct {
 // Global Settings structure containing the script parameters
 var settings Settings
 
-// Global Project structure containing the project details
-var project Project
+// Global slice containing every watched project
+var projects []*Project
 
-// Obtain the data about the project and store it in the `project` global variable
-func getProjectData() {
-	data := getProjectJSON()
+// Limits how many project pages can be fetched at the same time across all projects
+var fetchSemaphore chan struct{}
+
+// Whether --test-notification was passed; the test itself runs in main(), once every project has
+// been fetched and its watch list finalized, so the "test" template has real project context to render
+var testNotificationRequested bool
+
+// Matches a project specification carrying an explicit "URL:price1,price2" reward selection
+var projectSpecRegexp = regexp.MustCompile(`^(.+):(\d+(?:,\d+)*)$`)
+
+// Matches the JSON blob Kickstarter embeds in a <script> tag of the project description page
+var jsonRegexp = regexp.MustCompile(`window\.current_project\s*=\s*"(\{.*\})"`)
+
+// Split a "URL" or "URL:price1,price2" project specification into its URL and reward selection
+func parseProjectSpec(spec string) (rawURL string, rewards []int, err error) {
+	match := projectSpecRegexp.FindStringSubmatch(spec)
+	if match == nil {
+		return spec, nil, nil
+	}
+	for _, p := range str.Split(match[2], ",") {
+		price, convErr := strconv.Atoi(p)
+		if convErr != nil {
+			return "", nil, fmt.Errorf("invalid reward price %q: %w", p, convErr)
+		}
+		rewards = append(rewards, price)
+	}
+	return match[1], rewards, nil
+}
+
+// Obtain the data about a project and store it in its `Project` structure. Returns a non-nil
+// error if the project could not be fetched after exhausting the retry policy
+func getProjectData(p *Project) error {
+	data, changed, err := getProjectJSON(p)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	// The first time, get immutable data
-	if !project.initialized {
-		project.name = data["name"].(string)
-		project.currency_symbol = data["currency_symbol"].(string)
-		project.rewards = map[int]*Reward{}
+	if !p.initialized {
+		p.name = data["name"].(string)
+		p.currency_symbol = data["currency_symbol"].(string)
+		p.rewards = map[int]*Reward{}
 		for _, r := range data["rewards"].([]interface{}) {
 			reward := r.(map[string]interface{})
 			_, limited := reward["limit"]
 			if limited && reward["remaining"].(float64) == 0 {
 				id := int(reward["id"].(float64))
-				project.rewards[id] = &Reward{
+				p.rewards[id] = &Reward{
 					title:            reward["title"].(string),
 					title_with_price: reward["title_for_backing_tier"].(string),
 					id:               id,
@@ -84,7 +136,7 @@ func getProjectData() {
 				}
 			}
 		}
-		project.initialized = true
+		p.initialized = true
 	}
 	// Get mutable data
 	for _, r := range data["rewards"].([]interface{}) {
@@ -92,34 +144,112 @@ func getProjectData() {
 		_, limited := reward["limit"]
 		if limited && reward["remaining"].(float64) == 0 {
 			id := int(reward["id"].(float64))
-			project.rewards[id].available = int(reward["remaining"].(float64))
-			project.rewards[id].limit = int(reward["limit"].(float64))
+			p.rewards[id].available = int(reward["remaining"].(float64))
+			p.rewards[id].limit = int(reward["limit"].(float64))
 		}
 	}
+	return nil
 }
 
-// Download the project description page and return the unmarshalled JSON object containing the project data
-func getProjectJSON() map[string]interface{} {
-	res, err := http.Get(settings.url)
+// Send a conditional GET for the project description page, guarded by the concurrency limiter
+func fetchProjectPage(p *Project) (*http.Response, error) {
+	fetchSemaphore <- struct{}{}
+	defer func() { <-fetchSemaphore }()
+
+	req, err := http.NewRequest(http.MethodGet, p.url, nil)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	if p.etag != "" {
+		req.Header.Set("If-None-Match", p.etag)
+	}
+	if p.lastModified != "" {
+		req.Header.Set("If-Modified-Since", p.lastModified)
 	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		log.Fatalf(
-			"Could not get the project description, got HTTP response %d: \"%s\"",
-			res.StatusCode,
-			res.Status)
+	return p.client.Do(req)
+}
+
+// permanentFetchError marks an error for which retrying can never help (e.g. the project was
+// unpublished), as opposed to a transient error where getProjectJSON merely exhausted its retry
+// budget for this attempt and should be retried again on the next poll
+type permanentFetchError struct {
+	error
+}
+
+// Download the project description page and return the unmarshalled JSON object containing the
+// project data, along with whether it actually changed since the last successful fetch. Retries
+// network errors and 5xx with exponential backoff and jitter, capped at settings.backoffMax, and
+// 429 after its Retry-After delay (or the current backoff delay if absent), up to
+// settings.maxRetries retries (settings.maxRetries+1 attempts) before giving up and returning an error.
+// A 404 is not transient: it aborts immediately without consuming any retry attempt, wrapped in a
+// permanentFetchError so callers can tell it apart from a merely-exhausted-for-now retry budget
+func getProjectJSON(p *Project) (data map[string]interface{}, changed bool, err error) {
+	backoff := time.Second
+	for attempt := 1; ; attempt++ {
+		res, fetchErr := fetchProjectPage(p)
+		if fetchErr != nil {
+			if attempt > settings.maxRetries {
+				return nil, false, fmt.Errorf("giving up on %q after %d attempts: %w", p.url, attempt, fetchErr)
+			}
+			log.Printf("Network error fetching %q (attempt %d/%d): %s, retrying in %s", p.url, attempt, settings.maxRetries+1, fetchErr, backoff)
+			time.Sleep(withJitter(backoff))
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		switch {
+		case res.StatusCode == http.StatusNotModified:
+			res.Body.Close()
+			return nil, false, nil
+
+		case res.StatusCode == http.StatusOK:
+			projectDetails, parseErr := parseProjectJSON(res.Body)
+			res.Body.Close()
+			if parseErr != nil {
+				return nil, false, parseErr
+			}
+			p.etag = res.Header.Get("ETag")
+			p.lastModified = res.Header.Get("Last-Modified")
+			return projectDetails, true, nil
+
+		case res.StatusCode == http.StatusTooManyRequests:
+			wait := retryAfter(res.Header.Get("Retry-After"), backoff)
+			res.Body.Close()
+			if attempt > settings.maxRetries {
+				return nil, false, fmt.Errorf("giving up on %q after %d attempts, still rate-limited", p.url, attempt)
+			}
+			log.Printf("Rate-limited fetching %q, retrying in %s", p.url, wait)
+			time.Sleep(wait)
+			continue
+
+		case res.StatusCode == http.StatusNotFound:
+			res.Body.Close()
+			return nil, false, permanentFetchError{fmt.Errorf("project %q is gone (HTTP 404), it may have been unpublished or renamed", p.url)}
+
+		case res.StatusCode >= 500:
+			res.Body.Close()
+			if attempt > settings.maxRetries {
+				return nil, false, fmt.Errorf("giving up on %q after %d attempts, last response was HTTP %d", p.url, attempt, res.StatusCode)
+			}
+			log.Printf("Could not get the project description for %q, got HTTP response %d (attempt %d/%d), retrying in %s", p.url, res.StatusCode, attempt, settings.maxRetries+1, backoff)
+			time.Sleep(withJitter(backoff))
+			backoff = nextBackoff(backoff)
+			continue
+
+		default:
+			res.Body.Close()
+			return nil, false, fmt.Errorf("could not get the project description for %q, got HTTP response %d: %q", p.url, res.StatusCode, res.Status)
+		}
 	}
+}
 
-	// Load the HTML document
-	description, err := goquery.NewDocumentFromReader(res.Body)
+// Parse the HTML document and extract the JSON describing the project, embedded in a <script> tag
+func parseProjectJSON(body io.Reader) (map[string]interface{}, error) {
+	description, err := goquery.NewDocumentFromReader(body)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	// Parse the HTML and extract the JSON describing the project
-	jsonRegexp := regexp.MustCompile(`window\.current_project\s*=\s*"(\{.*\})"`)
 	var projectDetails map[string]interface{}
 	description.Find("script").EachWithBreak(func(i int, s *goquery.Selection) bool {
 		match := jsonRegexp.FindStringSubmatch(s.Text())
@@ -130,42 +260,86 @@ func getProjectJSON() map[string]interface{} {
 		}
 		return true
 	})
-	return projectDetails
+	return projectDetails, nil
+}
+
+// Return the next backoff delay, growing by settings.backoffFactor and capped at settings.backoffMax
+func nextBackoff(current time.Duration) time.Duration {
+	next := time.Duration(float64(current) * settings.backoffFactor)
+	if next > settings.backoffMax {
+		next = settings.backoffMax
+	}
+	return next
+}
+
+// Return base jittered by up to ±25%, so many failing projects don't retry in lockstep
+func withJitter(base time.Duration) time.Duration {
+	quarter := int64(base / 4)
+	if quarter <= 0 {
+		return base
+	}
+	return base + time.Duration(rand.Int63n(2*quarter+1)-quarter)
 }
 
-// Parse flags and store the results in the `settings` global variable
+// Parse a Retry-After header, which may be a number of seconds or an HTTP date, falling back to
+// the current backoff delay if it is absent or malformed
+func retryAfter(header string, backoff time.Duration) time.Duration {
+	if header == "" {
+		return backoff
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(when); wait > 0 {
+			return wait
+		}
+	}
+	return backoff
+}
+
+// Parse flags and store the results in the `settings` and `projects` global variables
 func parseArgs() {
 	// Define flags
-	pflag.IntSliceP("rewards", "r", []int{}, "Comma-separated list of unavailable limited rewards to watch, identified by their price in the project's original currency. If multiple limited rewards share the same price, all are watched. Ignored if --all is set")
-	pflag.BoolP("all", "a", false, "If set, watch all unavailable limited rewards")
-	pflag.DurationVarP(&settings.interval, "interval", "i", time.Minute, "Interval between checks")
+	pflag.IntSliceP("rewards", "r", []int{}, "Comma-separated list of unavailable limited rewards to watch, identified by their price in the project's original currency. Used for any project without its own \"URL:price1,price2\" selection. Ignored if --all is set")
+	pflag.BoolP("all", "a", false, "If set, watch all unavailable limited rewards, on every project")
+	pflag.DurationVarP(&settings.interval, "interval", "i", time.Minute, "Interval between checks, jittered per project so many projects don't get polled in the same second")
 	pflag.BoolVarP(&settings.quiet, "quiet", "q", false, "Quiet mode")
+	pflag.IntVar(&settings.maxConcurrentFetches, "max-concurrent-fetches", 4, "Maximum number of project pages fetched at the same time")
+	pflag.IntVar(&settings.maxRetries, "max-retries", 5, "Maximum number of retries after a transient failure before giving up on a project until the next poll")
+	pflag.DurationVar(&settings.backoffMax, "backoff-max", 30*time.Minute, "Upper bound on the exponential backoff delay between retries")
+	pflag.Float64Var(&settings.backoffFactor, "backoff-factor", 2, "Multiplier applied to the backoff delay after each failed attempt")
+	projectFlag := pflag.StringArrayP("project", "p", []string{}, `Project to watch, in addition to any positional URL. Accepts "URL" or "URL:price1,price2" to pick its rewards, can be repeated`)
 	notificationTest := pflag.BoolP("test-notification", "t", false, "Send a test notification at script start, fail if any configured notifier fails")
 	help := pflag.BoolP("help", "h", false, "Display this help")
 
-	// Setup the notifiers flags
-	for _, notifier := range settings.notifiers {
-		for _, flag := range notifier.Flags {
-			switch flag.ValueType {
-			case "string":
-				pflag.StringP(flag.Long, "", "", flag.Help)
-			case "int":
-				pflag.IntP(flag.Long, "", 0, flag.Help)
-			case "bool":
-				pflag.BoolP(flag.Long, "", false, flag.Help)
-			default:
-				log.Fatalf(
-					`Error in notifier "%s": "%s" is not supported as a notifier flag type\n`,
-					notifier.Name,
-					flag.ValueType)
-			}
-		}
-	}
+	// Notifiers are configured through Shoutrrr-style URLs, e.g. telegram://token@telegram?chats=123456
+	notificationURLs := pflag.StringArray("notification-url", []string{}, "URL of a notifier to send alerts to, can be repeated. See the README for the supported schemes")
+
+	// Deprecated: kept for backward compatibility, converted to a telegram:// URL below
+	tgToken := pflag.String("tg-token", "", "Deprecated, use --notification-url instead: Telegram notifier bot authentication token")
+	tgUserID := pflag.Int("tg-user-id", 0, "Deprecated, use --notification-url instead: User ID of the user to notify")
+
+	// Email notifier flags, converted to a smtp:// notification URL below
+	emailSMTPHost := pflag.String("email-smtp-host", "", "SMTP server hostname, enables the email notifier")
+	emailSMTPPort := pflag.Int("email-smtp-port", 587, "SMTP server port")
+	emailUsername := pflag.String("email-username", "", "SMTP AUTH username")
+	emailPassword := pflag.String("email-password", "", "SMTP AUTH password")
+	emailFrom := pflag.String("email-from", "", "Envelope and \"From\" address of the notification emails")
+	emailTo := pflag.StringArray("email-to", []string{}, "Recipient of the notification emails, can be repeated")
+	emailTLS := pflag.String("email-tls", "starttls", `TLS mode for the SMTP connection: "starttls", "tls" or "none"`)
+
+	// Notification message templates, each accepting either inline text or "@/path/to/file.tmpl"
+	templateAvailable := pflag.String("template-available", "", "Go template for the message sent when a watched reward becomes available")
+	templateTest := pflag.String("template-test", "", "Go template for the test notification")
+	templateStartup := pflag.String("template-startup", "", "Go template for the message sent once a project's watch list is finalized")
+	telegramTemplateAvailable := pflag.String("telegram-template-available", "", "Overrides --template-available for the Telegram notifier")
+	emailTemplateAvailable := pflag.String("email-template-available", "", "Overrides the HTML alternative rendered alongside --template-available for the email notifier")
 
 	// Configure and parse the flags
 	pflag.CommandLine.SortFlags = false
 	pflag.Usage = func() {
-		fmt.Printf("Usage: kickstarter-reward-notifier [OPTION] PROJECT_URL\n")
+		fmt.Printf("Usage: kickstarter-reward-notifier [OPTION] PROJECT_URL...\n")
 		pflag.PrintDefaults()
 	}
 	pflag.Parse()
@@ -176,101 +350,207 @@ func parseArgs() {
 		os.Exit(0)
 	}
 
-	// Get the notifiers flags values
-	for _, notifier := range settings.notifiers {
-		for _, flag := range notifier.Flags {
-			switch flag.ValueType {
-			case "string":
-				flag.Value, _ = pflag.CommandLine.GetString(flag.Long)
-			case "int":
-				flag.Value, _ = pflag.CommandLine.GetInt(flag.Long)
-			case "bool":
-				flag.Value, _ = pflag.CommandLine.GetBool(flag.Long)
-			}
-		}
+	// Get and validate the project specifications, from positional arguments and --project, and
+	// finalize the `projects` global before any notifier is initialized: the Telegram notifier
+	// starts its bot's long-polling loop as soon as it is built, and its handlers read `projects`
+	// through the `controller` type with no synchronization of their own
+	specs := append([]string{}, pflag.Args()...)
+	specs = append(specs, *projectFlag...)
+	if len(specs) == 0 {
+		pflag.Usage()
+		fmt.Println("Invalid argument: at least one project URL must be passed, either positionally or via --project.")
+		os.Exit(1)
 	}
 
-	// Test the notifiers
-	if *notificationTest {
-		fmt.Println("Testing the notifications...")
-		err := notifications.TestNotifiers()
+	fetchSemaphore = make(chan struct{}, settings.maxConcurrentFetches)
+	for _, spec := range specs {
+		rawURL, rewards, err := parseProjectSpec(spec)
 		if err != nil {
-			fmt.Printf("Failure during notification test: %s", err)
+			fmt.Printf("Invalid project specification %q: %s\n", spec, err)
 			os.Exit(1)
-		} else {
-			fmt.Println("All configured notifiers passed the test.")
 		}
+		projectURL, err := url.ParseRequestURI(rawURL)
+		if err != nil {
+			fmt.Printf("Project URL not valid: %s\n", err)
+			os.Exit(1)
+		}
+		projectURL.RawQuery = "" // Remove the query string
+		normalized := projectURL.String()
+		if !str.HasSuffix(normalized, "/description") {
+			normalized += "/description"
+		}
+		jar, err := cookiejar.New(nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		projects = append(projects, &Project{
+			url:             normalized,
+			client:          &http.Client{Jar: jar},
+			explicitRewards: rewards,
+			// Initialized here, not only in registerWatchedRewards: the Telegram bot's handlers
+			// can reach this project as soon as InitNotifiers starts its polling loop, well
+			// before its watch list is finalized, and must never see a nil map
+			watch: map[int]*Reward{},
+		})
 	}
 
-	// Get and validate the project URL
-	if len(pflag.Args()) != 1 {
-		pflag.Usage()
-		fmt.Printf("Invalid argument: there must be a single URL passed as parameter.\n")
+	// Convert the deprecated Telegram flags into the equivalent notification URL. --tg-token alone
+	// is a valid, pre-existing use case (the chat registers itself with /start later), so only
+	// append "chats=" when --tg-user-id was actually set, instead of baking in a phantom chat 0.
+	urls := append([]string{}, *notificationURLs...)
+	if *tgToken != "" || *tgUserID != 0 {
+		fmt.Println(`Warning: --tg-token/--tg-user-id are deprecated, use --notification-url "telegram://<token>@telegram?chats=<user-id>" instead.`)
+		tgURL := fmt.Sprintf("telegram://%s@telegram", *tgToken)
+		if *tgUserID != 0 {
+			tgURL += fmt.Sprintf("?chats=%d", *tgUserID)
+		}
+		urls = append(urls, tgURL)
+	}
+
+	// Convert the --email-* flags into the equivalent smtp:// notification URL
+	if *emailSMTPHost != "" {
+		emailURL := url.URL{Scheme: "smtp", Host: fmt.Sprintf("%s:%d", *emailSMTPHost, *emailSMTPPort)}
+		if *emailUsername != "" || *emailPassword != "" {
+			emailURL.User = url.UserPassword(*emailUsername, *emailPassword)
+		}
+		query := url.Values{}
+		query.Set("from", *emailFrom)
+		query.Set("to", str.Join(*emailTo, ","))
+		query.Set("tls", *emailTLS)
+		emailURL.RawQuery = query.Encode()
+		urls = append(urls, emailURL.String())
+	}
+
+	if err := notifications.InitNotifiers(urls); err != nil {
+		fmt.Printf("Invalid notifier configuration: %s\n", err)
 		os.Exit(1)
 	}
-	projectURL, err := url.ParseRequestURI(pflag.Arg(0))
-	if err != nil {
-		fmt.Printf("Project URL not valid: %s", err)
+
+	// Parse and validate every notification template up front, so a typo fails fast instead of
+	// surfacing mid-poll
+	overrides := map[string]string{}
+	if *telegramTemplateAvailable != "" {
+		overrides["telegram.available"] = *telegramTemplateAvailable
+	}
+	if *emailTemplateAvailable != "" {
+		overrides["email.available.html"] = *emailTemplateAvailable
+	}
+	if err := loadTemplates(*templateAvailable, *templateTest, *templateStartup, overrides); err != nil {
+		fmt.Printf("Invalid notification template: %s\n", err)
 		os.Exit(1)
 	}
-	projectURL.RawQuery = "" // Remove the query string
-	if str.HasSuffix(projectURL.String(), "/description") {
-		settings.url = projectURL.String()
-	} else {
-		settings.url = projectURL.String() + "/description"
+
+	// The test itself runs in main(), once every project has been fetched and its watch list
+	// finalized, so the "test" template has the same project and reward context as "startup"
+	testNotificationRequested = *notificationTest
+}
+
+// Build the context shared by the "test" notification from every project's finalized watch list
+func combinedStatusContext() StatusContext {
+	names := make([]string, len(projects))
+	urls := make([]string, len(projects))
+	rewards := []TemplateReward{}
+	for i, p := range projects {
+		p.mu.Lock()
+		names[i] = p.name
+		urls[i] = p.url
+		for _, w := range p.watch {
+			rewards = append(rewards, w.toTemplateReward())
+		}
+		p.mu.Unlock()
+	}
+	return StatusContext{
+		ProjectName: str.Join(names, ", "),
+		ProjectURL:  str.Join(urls, ", "),
+		Rewards:     rewards,
+		Timestamp:   time.Now(),
 	}
 }
 
-// Determine the rewards to watch
-func registerWatchedRewards() {
-	if len(project.rewards) == 0 {
-		fmt.Println("All of this project rewards are currently available.")
-		os.Exit(0)
+// Determine the rewards to watch for a single project
+func registerWatchedRewards(p *Project) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.rewards) == 0 {
+		fmt.Printf("All of %q's rewards are currently available.\n", p.name)
+		p.watch = map[int]*Reward{}
+		return
 	}
-	settings.watch = map[int]*Reward{}
+	p.watch = map[int]*Reward{}
 	watchAll, _ := pflag.CommandLine.GetBool("all")
-	watchList, _ := pflag.CommandLine.GetIntSlice("rewards")
+	watchList := p.explicitRewards
+	if len(watchList) == 0 {
+		watchList, _ = pflag.CommandLine.GetIntSlice("rewards")
+	}
 	if watchAll {
-		settings.watch = project.rewards
+		// Copy rather than alias p.rewards: Watch/Unwatch delete from p.watch, and deleting
+		// straight out of p.rewards would make getProjectData's mutable-data update panic on
+		// a nil *Reward the next time it looked up that now-missing ID
+		for id, r := range p.rewards {
+			p.watch[id] = r
+		}
 	} else if len(watchList) != 0 {
 		for _, price := range watchList {
-			r := findRewardsByPrice(price)
+			r := findRewardsByPrice(p, price)
 			if len(r) == 0 {
-				fmt.Printf("There is no limited and unavailable reward priced at %d%s, ignoring.\n", price, project.currency_symbol)
+				fmt.Printf("There is no limited and unavailable reward priced at %d%s for %q, ignoring.\n", price, p.currency_symbol, p.name)
 			} else {
 				for i := range r {
-					settings.watch[i] = project.rewards[i]
+					p.watch[i] = p.rewards[i]
 				}
 			}
 		}
 	}
 
 	// Prompt the user if no reward was specified
-	if len(settings.watch) == 0 {
-		askRewardsToWatch([]Reward{})
+	if len(p.watch) == 0 {
+		askRewardsToWatch(p)
 	}
 
 	// Display list of watched rewards
-	summary := fmt.Sprintf("%d rewards watched:\n", len(settings.watch))
-	for _, w := range settings.watch {
+	summary := fmt.Sprintf("%d rewards watched for %q:\n", len(p.watch), p.name)
+	for _, w := range p.watch {
 		summary += fmt.Sprintf("- %s\n", w.title_with_price)
 	}
 	fmt.Print(summary)
+
+	// Let every configured notifier know this project's watch list has been finalized
+	err := notifications.SendNotification(func(notifierName string) (string, string, error) {
+		return renderWithHTML("startup", notifierName, statusContext(p))
+	})
+	if err != nil {
+		log.Printf("Failed to send the startup notification for %q: %s", p.name, err)
+	}
 }
 
-// Prompt the user to interactively choose which limited rewards should be watched
-func askRewardsToWatch(rewards []Reward) {
+// Build the context shared by the "test" and "startup" templates from a project's watch list
+func statusContext(p *Project) StatusContext {
+	rewards := make([]TemplateReward, 0, len(p.watch))
+	for _, w := range p.watch {
+		rewards = append(rewards, w.toTemplateReward())
+	}
+	return StatusContext{
+		ProjectName:    p.name,
+		ProjectURL:     p.url,
+		CurrencySymbol: p.currency_symbol,
+		Rewards:        rewards,
+		Timestamp:      time.Now(),
+	}
+}
+
+// Prompt the user to interactively choose which limited rewards should be watched for a project
+func askRewardsToWatch(p *Project) {
 	i := 0
 	// Map the prompt index to the reward ID
 	rewardIndex := map[int]*Reward{}
 	choices := []string{}
-	for _, reward := range project.rewards {
+	for _, reward := range p.rewards {
 		choices = append(choices, fmt.Sprintf("%s (%d backers)", reward.title_with_price, reward.limit))
 		rewardIndex[i] = reward
 		i++
 	}
 	prompt := &survey.MultiSelect{
-		Message:  "Please select the rewards to watch:",
+		Message:  fmt.Sprintf("Please select the rewards to watch for %q:", p.name),
 		Options:  choices,
 		PageSize: 100,
 	}
@@ -278,14 +558,14 @@ func askRewardsToWatch(rewards []Reward) {
 	survey.AskOne(prompt, &selection, survey.WithValidator(survey.Required))
 	for _, i := range selection {
 		id := rewardIndex[i].id
-		settings.watch[id] = rewardIndex[i]
+		p.watch[id] = rewardIndex[i]
 	}
 }
 
-// Return a slice containing the IDs of all rewards at the specified price
-func findRewardsByPrice(price int) []int {
+// Return a slice containing the IDs of all of a project's rewards at the specified price
+func findRewardsByPrice(p *Project, price int) []int {
 	rewards := []int{}
-	for i, r := range project.rewards {
+	for i, r := range p.rewards {
 		if r.price == price {
 			rewards = append(rewards, i)
 		}
@@ -293,31 +573,205 @@ func findRewardsByPrice(price int) []int {
 	return rewards
 }
 
-//  Script entrypoint
-func main() {
-	settings.notifiers = notifications.InitNotifiers()
-	parseArgs()
-	// Get the project data and rewards list
-	getProjectData()
-	registerWatchedRewards()
+// Return a slice containing the ID of a project's reward with the given ID if it exists, falling
+// back to every reward at that price otherwise
+func findRewardsByIDOrPrice(p *Project, idOrPrice int) []int {
+	if _, ok := p.rewards[idOrPrice]; ok {
+		return []int{idOrPrice}
+	}
+	return findRewardsByPrice(p, idOrPrice)
+}
+
+// controller implements notifications.WatchController, letting the Telegram bot inspect and
+// mutate the watch list of every project concurrently with their poll loops
+type controller struct{}
+
+// List returns every limited reward tracked across every project
+func (controller) List() []notifications.Reward {
+	rewards := []notifications.Reward{}
+	for _, p := range projects {
+		p.mu.Lock()
+		for _, r := range p.rewards {
+			rewards = append(rewards, r.toNotificationReward(p))
+		}
+		p.mu.Unlock()
+	}
+	return rewards
+}
+
+// Watch adds the reward matching the given ID or price, on whichever project has it, to its watch list
+func (controller) Watch(idOrPrice int) error {
+	for _, p := range projects {
+		p.mu.Lock()
+		matches := findRewardsByIDOrPrice(p, idOrPrice)
+		for _, id := range matches {
+			p.watch[id] = p.rewards[id]
+		}
+		p.mu.Unlock()
+		if len(matches) != 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("no reward found with ID or price %d", idOrPrice)
+}
+
+// Unwatch removes the reward matching the given ID or price, on whichever project has it, from its watch list
+func (controller) Unwatch(idOrPrice int) error {
+	for _, p := range projects {
+		p.mu.Lock()
+		matches := findRewardsByIDOrPrice(p, idOrPrice)
+		for _, id := range matches {
+			delete(p.watch, id)
+		}
+		p.mu.Unlock()
+		if len(matches) != 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("no reward found with ID or price %d", idOrPrice)
+}
+
+// Status returns a human-readable summary of the polling interval and every project's watch list
+func (controller) Status() string {
+	status := fmt.Sprintf("Polling %d project(s) every %s.\n", len(projects), settings.interval)
+	for _, p := range projects {
+		p.mu.Lock()
+		status += fmt.Sprintf("%q: %d rewards watched:\n", p.name, len(p.watch))
+		for _, w := range p.watch {
+			status += fmt.Sprintf("- %s\n", w.title_with_price)
+		}
+		p.mu.Unlock()
+	}
+	return status
+}
+
+// Stop terminates the program
+func (controller) Stop() {
+	fmt.Println("Stop requested from a notifier, exiting.")
+	os.Exit(0)
+}
+
+// Convert a Reward to the decoupled representation expected by the notifications package
+func (r *Reward) toNotificationReward(p *Project) notifications.Reward {
+	return notifications.Reward{
+		ID:             r.id,
+		Title:          r.title,
+		TitleWithPrice: r.title_with_price,
+		Price:          r.price,
+		Available:      r.available,
+		Limit:          r.limit,
+		ProjectName:    p.name,
+	}
+}
+
+// Return settings.interval jittered by up to settings.interval/4 in either direction, so many
+// projects don't get polled in the same second
+func jitteredInterval() time.Duration {
+	maxJitter := int64(settings.interval / 4)
+	if maxJitter <= 0 {
+		return settings.interval
+	}
+	jitter := rand.Int63n(2*maxJitter+1) - maxJitter
+	return settings.interval + time.Duration(jitter)
+}
+
+// Poll a single project forever, sending a notification whenever one of its watched rewards becomes
+// available. Stops polling this project, without affecting the others, on a permanent failure
+// (HTTP 404); a transient failure that exhausted the retry policy is tried again on the next poll
+func pollProject(p *Project) {
 	for {
-		time.Sleep(settings.interval)
-		getProjectData()
+		time.Sleep(jitteredInterval())
+		p.mu.Lock()
+		wasInitialized := p.initialized
+		p.mu.Unlock()
+		if err := getProjectData(p); err != nil {
+			var permanent permanentFetchError
+			if errors.As(err, &permanent) {
+				log.Printf("Giving up on %q: %s", p.url, err)
+				return
+			}
+			// A transient failure merely exhausted its retry budget for this attempt; resume
+			// watching this project on the next poll rather than abandoning it for good
+			log.Printf("Could not refresh %q, will retry on the next poll: %s", p.url, err)
+			continue
+		}
+		// This project never got a chance to finalize its watch list at startup because its
+		// first fetch failed transiently; do it now that data has finally come in
+		if !wasInitialized {
+			registerWatchedRewards(p)
+		}
+		p.mu.Lock()
 		found := false
-		for _, r := range settings.watch {
+		for _, r := range p.watch {
 			if r.available > 0 {
 				found = true
-				message := fmt.Sprintf(`%d/%d of reward "%s" available!`,
-					r.available,
-					r.limit,
-					r.title_with_price)
-				notifMessage := fmt.Sprintf(`Alert about Kickstarter project "%s": %s`, project.name, message)
-				log.Printf(`\n%s\n`, message)
-				notifications.SendNotification(notifMessage)
+				log.Printf(`\n%d/%d of reward "%s" available on %q!\n`, r.available, r.limit, r.title_with_price, p.name)
+				ctx := AlertContext{
+					ProjectName:    p.name,
+					ProjectURL:     p.url,
+					CurrencySymbol: p.currency_symbol,
+					Reward:         r.toTemplateReward(),
+					Timestamp:      time.Now(),
+				}
+				err := notifications.SendNotification(func(notifierName string) (string, string, error) {
+					return renderWithHTML("available", notifierName, ctx)
+				})
+				if err != nil {
+					log.Printf("Failed to send the availability notification for %q: %s", p.name, err)
+				}
 			}
 		}
+		p.mu.Unlock()
 		if !found && !settings.quiet {
 			fmt.Print(".")
 		}
 	}
 }
+
+// Script entrypoint
+func main() {
+	notifications.SetWatchController(controller{})
+	parseArgs()
+
+	// Get the initial project data and rewards list, then prompt for each project in sequence.
+	// A permanent failure (e.g. HTTP 404) means this project can never be watched, but must not
+	// take down the other, already-initialized projects; a transient one just defers this
+	// project's watch list to its first successful poll, in pollProject.
+	for _, p := range projects {
+		if err := getProjectData(p); err != nil {
+			var permanent permanentFetchError
+			if errors.As(err, &permanent) {
+				log.Printf("Giving up on %q: %s", p.url, err)
+			} else {
+				log.Printf("Could not fetch %q on startup, will keep retrying: %s", p.url, err)
+			}
+			continue
+		}
+		registerWatchedRewards(p)
+	}
+
+	// Test the notifiers, now that every project's watch list is finalized
+	if testNotificationRequested {
+		fmt.Println("Testing the notifications...")
+		err := notifications.TestNotifiers(func(notifierName string) (string, string, error) {
+			return renderWithHTML("test", notifierName, combinedStatusContext())
+		})
+		if err != nil {
+			fmt.Printf("Failure during notification test: %s", err)
+			os.Exit(1)
+		} else {
+			fmt.Println("All configured notifiers passed the test.")
+		}
+	}
+
+	// Poll every project concurrently, each on its own jittered schedule
+	var wg sync.WaitGroup
+	for _, p := range projects {
+		wg.Add(1)
+		go func(p *Project) {
+			defer wg.Done()
+			pollProject(p)
+		}(p)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,159 @@
+/*
+Copyright (C) 2021 Victor Fauth <victor@fauth.pro>
+
+This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along with this program. If not, see https://www.gnu.org/licenses/.
+*/
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNextBackoff(t *testing.T) {
+	settings.backoffFactor = 2
+	settings.backoffMax = 10 * time.Second
+
+	if got := nextBackoff(time.Second); got != 2*time.Second {
+		t.Errorf("nextBackoff(1s) = %s, want 2s", got)
+	}
+	if got := nextBackoff(8 * time.Second); got != settings.backoffMax {
+		t.Errorf("nextBackoff(8s) = %s, want capped at %s", got, settings.backoffMax)
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	base := 4 * time.Second
+	quarter := base / 4
+	for i := 0; i < 100; i++ {
+		got := withJitter(base)
+		if got < base-quarter || got > base+quarter {
+			t.Fatalf("withJitter(%s) = %s, want within ±25%%", base, got)
+		}
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	if got := retryAfter("5", time.Second); got != 5*time.Second {
+		t.Errorf("retryAfter(\"5\", 1s) = %s, want 5s", got)
+	}
+}
+
+func TestRetryAfterFallsBackOnGarbage(t *testing.T) {
+	if got := retryAfter("not-a-valid-header", 3*time.Second); got != 3*time.Second {
+		t.Errorf("retryAfter(garbage, 3s) = %s, want fallback 3s", got)
+	}
+}
+
+// newTestProject builds a Project pointed at the given test server, bypassing parseArgs
+func newTestProject(t *testing.T, server *httptest.Server) *Project {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New: %s", err)
+	}
+	return &Project{
+		url:    server.URL,
+		client: &http.Client{Jar: jar},
+		watch:  map[int]*Reward{},
+	}
+}
+
+func TestGetProjectJSON404IsPermanentAndDoesNotRetry(t *testing.T) {
+	settings.maxRetries = 5
+	settings.backoffFactor = 2
+	settings.backoffMax = time.Minute
+	fetchSemaphore = make(chan struct{}, 1)
+
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, _, err := getProjectJSON(newTestProject(t, server))
+	if err == nil {
+		t.Fatal("expected an error for a 404 response, got nil")
+	}
+	var permanent permanentFetchError
+	if !errors.As(err, &permanent) {
+		t.Errorf("expected a permanentFetchError, got %T: %s", err, err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected exactly 1 request for a 404, got %d", attempts)
+	}
+}
+
+func TestGetProjectJSON5xxGivesUpTransiently(t *testing.T) {
+	settings.maxRetries = 0
+	settings.backoffFactor = 2
+	settings.backoffMax = time.Minute
+	fetchSemaphore = make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	_, _, err := getProjectJSON(newTestProject(t, server))
+	if err == nil {
+		t.Fatal("expected an error after exhausting retries on a 5xx response, got nil")
+	}
+	var permanent permanentFetchError
+	if errors.As(err, &permanent) {
+		t.Errorf("a 5xx error should not be a permanentFetchError, got %s", err)
+	}
+}
+
+func TestGetProjectJSONParsesTheEmbeddedProject(t *testing.T) {
+	settings.maxRetries = 0
+	fetchSemaphore = make(chan struct{}, 1)
+
+	const body = `<html><body><script>
+window.current_project = "{&quot;name&quot;: &quot;Test Project&quot;, &quot;currency_symbol&quot;: &quot;$&quot;, &quot;rewards&quot;: []}";
+</script></body></html>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	data, changed, err := getProjectJSON(newTestProject(t, server))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !changed {
+		t.Error("expected changed=true for a 200 OK response")
+	}
+	if data["name"] != "Test Project" {
+		t.Errorf("data[\"name\"] = %v, want %q", data["name"], "Test Project")
+	}
+}
+
+func TestGetProjectJSONNotModified(t *testing.T) {
+	settings.maxRetries = 0
+	fetchSemaphore = make(chan struct{}, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	_, changed, err := getProjectJSON(newTestProject(t, server))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if changed {
+		t.Error("expected changed=false for a 304 Not Modified response")
+	}
+}
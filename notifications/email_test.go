@@ -0,0 +1,63 @@
+/*
+Copyright (C) 2021 Victor Fauth <victor@fauth.pro>
+
+This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along with this program. If not, see https://www.gnu.org/licenses/.
+*/
+
+package notifications
+
+import (
+	str "strings"
+	"testing"
+)
+
+func testEmail() *Email {
+	return &Email{
+		from: "bot@example.com",
+		to:   []string{"you@example.com"},
+	}
+}
+
+func TestBuildPlainMessageIsQuotedPrintableEncoded(t *testing.T) {
+	body, err := testEmail().buildPlainMessage("Reward available: 10€ tier")
+	if err != nil {
+		t.Fatalf("buildPlainMessage: %s", err)
+	}
+	msg := string(body)
+
+	if !str.Contains(msg, "Content-Type: text/plain; charset=utf-8") {
+		t.Error("expected a text/plain Content-Type header")
+	}
+	if !str.Contains(msg, "Content-Transfer-Encoding: quoted-printable") {
+		t.Error("expected a quoted-printable Content-Transfer-Encoding header")
+	}
+	// The € sign is outside ASCII, so it must be quoted-printable encoded, not sent as a raw byte
+	if str.Contains(msg, "€") {
+		t.Error("expected the non-ASCII € sign to be quoted-printable encoded, found it raw in the message")
+	}
+}
+
+func TestBuildAlternativeMessageCarriesBothParts(t *testing.T) {
+	body, err := testEmail().buildAlternativeMessage("plain text", "<p>html body</p>")
+	if err != nil {
+		t.Fatalf("buildAlternativeMessage: %s", err)
+	}
+	msg := string(body)
+
+	if !str.Contains(msg, "Content-Type: multipart/alternative;") {
+		t.Error("expected a multipart/alternative Content-Type header")
+	}
+	if str.Count(msg, "Content-Transfer-Encoding: quoted-printable") != 2 {
+		t.Error("expected both the text/plain and text/html parts to be quoted-printable encoded")
+	}
+	if !str.Contains(msg, "Content-Type: text/plain; charset=utf-8") {
+		t.Error("expected a text/plain part")
+	}
+	if !str.Contains(msg, "Content-Type: text/html; charset=utf-8") {
+		t.Error("expected a text/html part")
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright (C) 2021 Victor Fauth <victor@fauth.pro>
+
+This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along with this program. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package notifications provides helpers to send notifications
+package notifications
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Notifier is implemented by every notification backend. Backends are configured from a
+// Shoutrrr-style URL (e.g. telegram://token@telegram?chats=123456) and registered under the
+// scheme they handle, so adding a new notification channel never requires touching this package.
+type Notifier interface {
+	// Send delivers message through this notifier, returning any transport error.
+	Send(message string) error
+	// Name returns a human-readable identifier for this notifier, used in logs and test output.
+	Name() string
+}
+
+// HTMLSender is an optional interface implemented by notifiers that can embed a richer,
+// independently-rendered HTML body alongside the plain-text message, such as the email notifier's
+// multipart/alternative part. Notifiers that don't implement it just get Send(plain) called.
+type HTMLSender interface {
+	SendHTML(plain, html string) error
+}
+
+// Builder constructs a configured Notifier from a parsed notification URL.
+type Builder func(u *url.URL) (Notifier, error)
+
+// registry maps a URL scheme to the Builder that knows how to construct that notifier.
+var registry = map[string]Builder{}
+
+// Register adds a notifier Builder to the registry, keyed by the URL scheme it handles.
+// It is meant to be called from each backend's init function.
+func Register(scheme string, builder Builder) {
+	registry[scheme] = builder
+}
+
+// Parse builds the list of notifiers described by the given Shoutrrr-style URLs, in order.
+func Parse(rawURLs []string) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(rawURLs))
+	for _, raw := range rawURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid notification URL %q: %w", raw, err)
+		}
+		builder, ok := registry[u.Scheme]
+		if !ok {
+			return nil, fmt.Errorf("no notifier registered for scheme %q (url: %s)", u.Scheme, raw)
+		}
+		notifier, err := builder(u)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure %q notifier: %w", u.Scheme, err)
+		}
+		notifiers = append(notifiers, notifier)
+	}
+	return notifiers, nil
+}
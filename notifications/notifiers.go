@@ -14,100 +14,74 @@ package notifications
 import (
 	"errors"
 	"fmt"
-	"reflect"
 )
 
-// Structure describing a notifier
-type Notifier struct {
-	Name  string           // Notifier name
-	Flags map[string]*Flag // Slice of all flags used to pass parameters
-}
-
-// Structure listing all notifiers
-type AllNotifiers struct {
-	notifiers []*Notifier // Pointers to every embedded Notifier struct
-	Telegram  Telegram    // Each notifier type embeds a Notifier struct
-}
-
-// Global variable containing each notifier
-var allNotifiers AllNotifiers
+// Global variable containing every notifier built from the configured notification URLs
+var activeNotifiers []Notifier
 
-// Structure describing a flag to pass notifiers parameters in the CLI
-type Flag struct {
-	Long      string      // Long name of the flag, required
-	Short     string      // Short name
-	Help      string      // Help message, required
-	ValueType string      // Type of the value: "string", "int" or "bool" are supported
-	Value     interface{} // Interface to hold the flag value
-}
-
-// Initialize all the notifiers and return them
-func InitNotifiers() []*Notifier {
-	allNotifiers.notifiers = make([]*Notifier, reflect.ValueOf(allNotifiers).NumField()-1)
-	for i := range allNotifiers.notifiers {
-		allNotifiers.notifiers[i] = &Notifier{}
+// Parse the given Shoutrrr-style notification URLs and store the resulting notifiers
+func InitNotifiers(urls []string) error {
+	notifiers, err := Parse(urls)
+	if err != nil {
+		return err
 	}
-	allNotifiers.Telegram = TelegramInit(allNotifiers.notifiers[0])
-	return allNotifiers.notifiers
+	activeNotifiers = notifiers
+	return nil
 }
 
-// Send a notification using all configured notifiers
-func SendNotification(message string) error {
-	for _, notifier := range allNotifiers.notifiers {
-		err := error(nil)
-		switch notifier.Name {
-		case "telegram":
-			err = allNotifiers.Telegram.Send(message)
-		}
+// deliverToAll runs render once per configured notifier and delivers its result, continuing
+// through every notifier even when one fails to send, so that a single failing notifier never
+// prevents the others from firing. render is called once per notifier, with its Name(), so that
+// per-notifier template overrides can be applied. Returns an aggregate error describing every
+// delivery failure, or nil if every notifier succeeded.
+func deliverToAll(render func(notifierName string) (plain string, html string, err error)) error {
+	failures := []error(nil)
+	for _, notifier := range activeNotifiers {
+		plain, html, err := render(notifier.Name())
 		if err != nil {
 			return err
 		}
+		if err := send(notifier, plain, html); err != nil {
+			failures = append(failures, err)
+		}
 	}
-	return nil
+	if len(failures) == 0 {
+		return nil
+	}
+	errorMessage := fmt.Sprintf("%d/%d notifiers failed:\n", len(failures), len(activeNotifiers))
+	for _, f := range failures {
+		errorMessage += f.Error() + "\n"
+	}
+	return errors.New(errorMessage)
 }
 
-// Send a notification using all configured notifiers
-func (notifier Notifier) IsConfigured() bool {
-	switch notifier.Name {
-	case "telegram":
-		return allNotifiers.Telegram.isConfigured()
-	default:
-		return false
-	}
+// Send a notification using all configured notifiers. render is called once per notifier, with
+// its Name(), so that per-notifier template overrides can be applied. It returns the plain-text
+// message and, optionally, a richer HTML-rendered variant (empty if none applies).
+func SendNotification(render func(notifierName string) (plain string, html string, err error)) error {
+	return deliverToAll(render)
 }
 
-// Test all enabled notifiers
-func TestNotifiers() error {
-	// Count the configured notifiers
-	enabled := 0
-	for _, n := range allNotifiers.notifiers {
-		if n.IsConfigured() {
-			enabled++
-		}
-	}
-	if enabled == 0 {
+// Test all configured notifiers. render is called once per notifier, with its Name(), so that
+// per-notifier template overrides can be applied. It returns the plain-text message and,
+// optionally, a richer HTML-rendered variant (empty if none applies).
+func TestNotifiers(render func(notifierName string) (plain string, html string, err error)) error {
+	if len(activeNotifiers) == 0 {
 		return errors.New("no notifier has been configured")
 	}
-
-	message := "This is a test notification"
-	failures := []error(nil)
-	for _, notifier := range allNotifiers.notifiers {
-		err := error(nil)
-		switch notifier.Name {
-		case "telegram":
-			err = allNotifiers.Telegram.Send(message)
-		}
-		if err != nil {
-			failures = append(failures, err)
-		}
+	if err := deliverToAll(render); err != nil {
+		return fmt.Errorf("failure while testing notifiers: %w", err)
 	}
-	if len(failures) != 0 {
-		errorMessage := fmt.Sprintf("Failure while testing notifiers: %d/%d notifiers returned an error.\n", len(failures), enabled)
-		for _, f := range failures {
-			errorMessage += f.Error() + "\n"
+	return nil
+}
+
+// send delivers the message through notifier, preferring its HTMLSender.SendHTML when it
+// implements that optional interface and a non-empty html variant was rendered
+func send(notifier Notifier, plain, html string) error {
+	if html != "" {
+		if htmlNotifier, ok := notifier.(HTMLSender); ok {
+			return htmlNotifier.SendHTML(plain, html)
 		}
-		return errors.New(errorMessage)
-	} else {
-		return nil
 	}
+	return notifier.Send(plain)
 }
@@ -0,0 +1,56 @@
+/*
+Copyright (C) 2021 Victor Fauth <victor@fauth.pro>
+
+This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along with this program. If not, see https://www.gnu.org/licenses/.
+*/
+
+package notifications
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeNotifier is a Notifier whose Send outcome and call count are inspectable from a test
+type fakeNotifier struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+func (f *fakeNotifier) Send(message string) error {
+	f.calls++
+	return f.err
+}
+
+func TestParseFailsOnUnregisteredScheme(t *testing.T) {
+	_, err := Parse([]string{"bogus://x"})
+	if err == nil {
+		t.Fatal("expected an error for a scheme with no registered Builder, got nil")
+	}
+}
+
+func TestDeliverToAllKeepsGoingPastAPartialFailure(t *testing.T) {
+	failing := &fakeNotifier{name: "failing", err: errors.New("boom")}
+	ok := &fakeNotifier{name: "ok"}
+	activeNotifiers = []Notifier{failing, ok}
+	defer func() { activeNotifiers = nil }()
+
+	err := deliverToAll(func(notifierName string) (string, string, error) {
+		return "hello", "", nil
+	})
+	if err == nil {
+		t.Fatal("expected an aggregate error describing the failing notifier")
+	}
+	if failing.calls != 1 {
+		t.Errorf("expected the failing notifier to be attempted once, got %d", failing.calls)
+	}
+	if ok.calls != 1 {
+		t.Errorf("expected the second notifier to still fire after the first one failed, got %d calls", ok.calls)
+	}
+}
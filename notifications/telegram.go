@@ -12,66 +12,183 @@ You should have received a copy of the GNU General Public License along with thi
 package notifications
 
 import (
+	"fmt"
 	"log"
+	"net/url"
+	"strconv"
+	str "strings"
+	"sync"
+	"time"
 
 	tb "gopkg.in/tucnak/telebot.v2"
 )
 
-// Structure storing the parameters required to send notifications with Telegram
+// Register the telegram:// scheme with the notifier registry
+func init() {
+	Register("telegram", newTelegram)
+}
+
+// Telegram is a Notifier that sends messages to one or more Telegram chats through a bot. Besides
+// pushing alerts, it runs a long-polling loop so users can drive the watch list interactively.
 type Telegram struct {
-	Notifier *Notifier
+	token   string         // Bot authentication token
+	bot     *tb.Bot        // Running bot instance
+	mu      sync.Mutex     // Guards chatIDs, which is read and written from concurrent bot handlers
+	chatIDs map[int64]bool // Set of chats to notify, grown by /start and pre-seeded from the URL
 }
 
-// Telegram notifier specification
-func TelegramInit(notifier *Notifier) Telegram {
-	tg := Telegram{Notifier: notifier}
-	tg.Notifier.Name = "telegram"
-	tg.Notifier.Flags = map[string]*Flag{
-		"token": {
-			Long:      "tg-token",
-			Short:     "",
-			Help:      "Telegram notifier: Bot authentication token",
-			ValueType: "string",
-		},
-		"userID": {
-			Long:      "tg-user-id",
-			Short:     "",
-			Help:      "Telegram notifier: User ID of the user to notify",
-			ValueType: "int",
-		},
+// Build a Telegram notifier from a telegram://token@telegram[?chats=<id>[,<id>...]] URL and start
+// its long-polling loop. The "chats" query parameter is optional: chats can instead register
+// themselves with /start.
+func newTelegram(u *url.URL) (Notifier, error) {
+	token := u.User.Username()
+	if token == "" {
+		return nil, fmt.Errorf(`telegram URL must carry the bot token, e.g. "telegram://token@telegram?chats=123456"`)
+	}
+
+	tg := &Telegram{token: token, chatIDs: map[int64]bool{}}
+	if chats := u.Query().Get("chats"); chats != "" {
+		for _, c := range str.Split(chats, ",") {
+			chatID, err := strconv.ParseInt(c, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid telegram chat ID %q: %w", c, err)
+			}
+			tg.chatIDs[chatID] = true
+		}
+	}
+
+	bot, err := tb.NewBot(tb.Settings{
+		Token:  token,
+		Poller: &tb.LongPoller{Timeout: 10 * time.Second},
+	})
+	if err != nil {
+		return nil, err
 	}
+	tg.bot = bot
+	tg.registerHandlers()
+	go bot.Start()
 
-	return tg
+	return tg, nil
 }
 
-// Return the token
-func (tg Telegram) token() string {
-	return tg.Notifier.Flags["token"].Value.(string)
+// Name returns this notifier's identifier
+func (tg *Telegram) Name() string {
+	return "telegram"
 }
 
-// Return the user ID
-func (tg Telegram) userID() int {
-	return tg.Notifier.Flags["userID"].Value.(int)
+// Send implements the sending of a notification to every registered Telegram chat
+func (tg *Telegram) Send(message string) error {
+	tg.mu.Lock()
+	chatIDs := make([]int64, 0, len(tg.chatIDs))
+	for id := range tg.chatIDs {
+		chatIDs = append(chatIDs, id)
+	}
+	tg.mu.Unlock()
+
+	var firstErr error
+	for _, id := range chatIDs {
+		log.Printf("Sending a Telegram notification to chat %d", id)
+		if _, err := tg.bot.Send(&tb.Chat{ID: id}, message); err != nil {
+			log.Printf("ERROR: Failed to send a Telegram notification to chat %d, got: %s", id, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
 }
 
-// Implement the sending of a notification to a Telegram user
-func (tg Telegram) Send(message string) error {
-	if tg.isConfigured() {
-		log.Printf("Sending a Telegram notification to user %d", tg.userID())
-		bot, _ := tb.NewBot(tb.Settings{Token: tg.token()})
-		user := &tb.User{ID: tg.userID()}
-		_, err := bot.Send(user, message)
-		if err != nil {
-			log.Printf("ERROR: Failed to send a Telegram notification to user %d, got: %s", tg.userID(), err)
+// Register the /start, /list, /watch, /unwatch, /status and /stop bot commands. Only /start is
+// open to anyone; every other command is gated on the chat having already registered, since bot
+// usernames (and leaked tokens) make the bot reachable by strangers.
+func (tg *Telegram) registerHandlers() {
+	tg.bot.Handle("/start", func(m *tb.Message) {
+		tg.mu.Lock()
+		tg.chatIDs[m.Chat.ID] = true
+		tg.mu.Unlock()
+		tg.bot.Send(m.Chat, "You are now registered for reward availability alerts.")
+	})
+
+	tg.bot.Handle("/list", func(m *tb.Message) {
+		if !tg.isRegistered(m.Chat.ID) {
+			return
+		}
+		if watchController == nil {
+			return
+		}
+		rewards := watchController.List()
+		if len(rewards) == 0 {
+			tg.bot.Send(m.Chat, "No limited reward found for this project.")
+			return
+		}
+		text := "Limited rewards:\n"
+		for _, r := range rewards {
+			text += fmt.Sprintf("- [%d] %s (%s): %d/%d available\n", r.ID, r.TitleWithPrice, r.ProjectName, r.Available, r.Limit)
+		}
+		tg.bot.Send(m.Chat, text)
+	})
+
+	tg.bot.Handle("/watch", func(m *tb.Message) {
+		tg.handleWatch(m, true)
+	})
+	tg.bot.Handle("/unwatch", func(m *tb.Message) {
+		tg.handleWatch(m, false)
+	})
+
+	tg.bot.Handle("/status", func(m *tb.Message) {
+		if !tg.isRegistered(m.Chat.ID) {
+			return
+		}
+		if watchController == nil {
+			return
 		}
+		tg.bot.Send(m.Chat, watchController.Status())
+	})
 
-		return err
-	}
-	return nil
+	tg.bot.Handle("/stop", func(m *tb.Message) {
+		if !tg.isRegistered(m.Chat.ID) {
+			return
+		}
+		if watchController == nil {
+			return
+		}
+		tg.bot.Send(m.Chat, "Stopping the notifier, goodbye!")
+		watchController.Stop()
+	})
 }
 
-// Implement checking whether Telegram notifications are enabled
-func (tg Telegram) isConfigured() bool {
-	// Both the token and the user ID must be defined
-	return tg.token() != "" && tg.userID() != 0
+// isRegistered reports whether chatID has registered with /start
+func (tg *Telegram) isRegistered(chatID int64) bool {
+	tg.mu.Lock()
+	defer tg.mu.Unlock()
+	return tg.chatIDs[chatID]
+}
+
+// Parse the <price|id> argument of /watch and /unwatch and apply it through the watch controller
+func (tg *Telegram) handleWatch(m *tb.Message, watch bool) {
+	if !tg.isRegistered(m.Chat.ID) {
+		return
+	}
+	if watchController == nil {
+		return
+	}
+	idOrPrice, err := strconv.Atoi(str.TrimSpace(m.Payload))
+	if err != nil {
+		tg.bot.Send(m.Chat, "Usage: /watch <price|id> (and /unwatch <price|id>)")
+		return
+	}
+	if watch {
+		err = watchController.Watch(idOrPrice)
+	} else {
+		err = watchController.Unwatch(idOrPrice)
+	}
+	if err != nil {
+		tg.bot.Send(m.Chat, fmt.Sprintf("Error: %s", err))
+		return
+	}
+	if watch {
+		tg.bot.Send(m.Chat, "Now watching that reward.")
+	} else {
+		tg.bot.Send(m.Chat, "No longer watching that reward.")
+	}
 }
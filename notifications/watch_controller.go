@@ -0,0 +1,47 @@
+/*
+Copyright (C) 2021 Victor Fauth <victor@fauth.pro>
+
+This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along with this program. If not, see https://www.gnu.org/licenses/.
+*/
+
+// Package notifications provides helpers to send notifications
+package notifications
+
+// Reward is a read-only snapshot of a watched Kickstarter reward, decoupled from main's own
+// representation so that this package never needs to import it back.
+type Reward struct {
+	ID             int    // Kickstarter ID of this reward
+	Title          string // Reward name
+	TitleWithPrice string // Reward name including its price
+	Price          int    // Reward price in the project original currency
+	Available      int    // Remaining number of this reward
+	Limit          int    // Total quantity of this reward
+	ProjectName    string // Name of the project this reward belongs to, to disambiguate across projects
+}
+
+// WatchController lets interactive notifiers (e.g. the Telegram bot) inspect and mutate the watch
+// list maintained by main, without this package needing to know about its internals.
+type WatchController interface {
+	// List returns every limited reward tracked across every watched project.
+	List() []Reward
+	// Watch adds the reward matching the given ID or price, on whichever project has it, to the watch list.
+	Watch(idOrPrice int) error
+	// Unwatch removes the reward matching the given ID or price, on whichever project has it, from the watch list.
+	Unwatch(idOrPrice int) error
+	// Status returns a human-readable summary of the polling interval and every project's watched rewards.
+	Status() string
+	// Stop terminates the program.
+	Stop()
+}
+
+// Global variable holding the controller registered by main, nil until SetWatchController is called
+var watchController WatchController
+
+// SetWatchController registers the controller used by interactive notifiers to drive the watch list
+func SetWatchController(c WatchController) {
+	watchController = c
+}
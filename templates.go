@@ -0,0 +1,276 @@
+/*
+Copyright (C) 2021 Victor Fauth <victor@fauth.pro>
+
+This program is free software: you can redistribute it and/or modify it under the terms of the GNU General Public License as published by the Free Software Foundation, either version 3 of the License, or (at your option) any later version.
+
+This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY; without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along with this program. If not, see https://www.gnu.org/licenses/.
+*/
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	str "strings"
+	"text/template"
+	"time"
+)
+
+// Default templates. The plain-text ones are overridden at runtime by
+// --template-available/--template-test/--template-startup; the HTML ones are always used, except
+// "available.html" for the email notifier when --email-template-available is set
+//
+//go:embed templates/*.tmpl
+var defaultTemplatesFS embed.FS
+
+// TemplateReward is the reward data made available to notification templates
+type TemplateReward struct {
+	ID             int    // Kickstarter ID of this reward
+	Title          string // Reward name
+	TitleWithPrice string // Reward name including its price
+	Price          int    // Reward price in the project original currency
+	Available      int    // Remaining number of this reward
+	Limit          int    // Total quantity of this reward
+}
+
+// AlertContext is the context passed to the "available" template, rendered once per reward that
+// just became available
+type AlertContext struct {
+	ProjectName    string
+	ProjectURL     string
+	CurrencySymbol string
+	Reward         TemplateReward
+	Timestamp      time.Time
+}
+
+// StatusContext is the context passed to the "test" and "startup" templates
+type StatusContext struct {
+	ProjectName    string
+	ProjectURL     string
+	CurrencySymbol string
+	Rewards        []TemplateReward
+	Timestamp      time.Time
+}
+
+// templateFuncs lists the helper functions available to every notification template
+var templateFuncs = template.FuncMap{
+	"formatPrice": formatPrice,
+	"percent":     percent,
+	"humanize":    humanize,
+}
+
+// Format a price using the project's currency symbol
+func formatPrice(price int, symbol string) string {
+	return fmt.Sprintf("%d%s", price, symbol)
+}
+
+// Return how far available is into limit, as a whole percentage
+func percent(available, limit int) int {
+	if limit == 0 {
+		return 0
+	}
+	return available * 100 / limit
+}
+
+// Spell out small counts, falling back to the number itself past ten
+func humanize(n int) string {
+	words := []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+	if n >= 0 && n < len(words) {
+		return words[n]
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// Templates holds the parsed templates used to render notification messages
+type Templates struct {
+	available     *template.Template     // Rendered once per reward that becomes available
+	test          *template.Template     // Rendered for --test-notification
+	startup       *template.Template     // Rendered once the watch list is finalized
+	availableHTML *htmltemplate.Template // Richer HTML layout of "available", for notifiers that send a separate HTML alternative
+	testHTML      *htmltemplate.Template // Richer HTML layout of "test"
+	startupHTML   *htmltemplate.Template // Richer HTML layout of "startup"
+}
+
+// Global Templates structure containing the parsed notification templates
+var templates Templates
+
+// Load a template from its --template-* flag value: empty uses the embedded default, a value
+// starting with "@" is read from that file path, anything else is used as inline template text
+func loadTemplate(name, spec, defaultFile string) (*template.Template, error) {
+	var text string
+	switch {
+	case spec == "":
+		b, err := defaultTemplatesFS.ReadFile("templates/" + defaultFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	case str.HasPrefix(spec, "@"):
+		b, err := os.ReadFile(str.TrimPrefix(spec, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("could not read template file: %w", err)
+		}
+		text = string(b)
+	default:
+		text = spec
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// Load an HTML template the same way loadTemplate does, but parsed with html/template so fields
+// interpolated from creator-controlled project data (titles, names) are escaped rather than
+// injected verbatim into the HTML body
+func loadHTMLTemplate(name, spec, defaultFile string) (*htmltemplate.Template, error) {
+	var text string
+	switch {
+	case spec == "":
+		b, err := defaultTemplatesFS.ReadFile("templates/" + defaultFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	case str.HasPrefix(spec, "@"):
+		b, err := os.ReadFile(str.TrimPrefix(spec, "@"))
+		if err != nil {
+			return nil, fmt.Errorf("could not read template file: %w", err)
+		}
+		text = string(b)
+	default:
+		text = spec
+	}
+	return htmltemplate.New(name).Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(text)
+}
+
+// Parse and validate the --template-available/--template-test/--template-startup templates, along
+// with any per-notifier override, failing fast on any parse error
+func loadTemplates(available, test, startup string, overrides map[string]string) error {
+	var err error
+	if templates.available, err = loadTemplate("available", available, "available.tmpl"); err != nil {
+		return fmt.Errorf("invalid --template-available: %w", err)
+	}
+	if templates.test, err = loadTemplate("test", test, "test.tmpl"); err != nil {
+		return fmt.Errorf("invalid --template-test: %w", err)
+	}
+	if templates.startup, err = loadTemplate("startup", startup, "startup.tmpl"); err != nil {
+		return fmt.Errorf("invalid --template-startup: %w", err)
+	}
+	if templates.availableHTML, err = loadHTMLTemplate("available.html", "", "available.html.tmpl"); err != nil {
+		return fmt.Errorf("invalid default available.html template: %w", err)
+	}
+	if templates.testHTML, err = loadHTMLTemplate("test.html", "", "test.html.tmpl"); err != nil {
+		return fmt.Errorf("invalid default test.html template: %w", err)
+	}
+	if templates.startupHTML, err = loadHTMLTemplate("startup.html", "", "startup.html.tmpl"); err != nil {
+		return fmt.Errorf("invalid default startup.html template: %w", err)
+	}
+	for key, spec := range overrides {
+		if str.HasSuffix(key, ".html") {
+			tmpl, err := loadHTMLTemplate(key, spec, "")
+			if err != nil {
+				return fmt.Errorf("invalid --%s-template-*: %w", key, err)
+			}
+			htmlTemplateOverrides[key] = tmpl
+			continue
+		}
+		tmpl, err := loadTemplate(key, spec, "")
+		if err != nil {
+			return fmt.Errorf("invalid --%s-template-*: %w", key, err)
+		}
+		templateOverrides[key] = tmpl
+	}
+	return nil
+}
+
+// templateOverrides holds per-notifier plain-text template overrides, keyed by
+// "<notifier name>.<kind>" (e.g. "telegram.available")
+var templateOverrides = map[string]*template.Template{}
+
+// htmlTemplateOverrides holds per-notifier HTML template overrides, keyed the same way (e.g.
+// "email.available.html")
+var htmlTemplateOverrides = map[string]*htmltemplate.Template{}
+
+// Render the given plain-text template kind for the given notifier, preferring a per-notifier
+// override
+func render(kind, notifierName string, data interface{}) (string, error) {
+	tmpl := templates.forKind(kind)
+	if override, ok := templateOverrides[notifierName+"."+kind]; ok {
+		tmpl = override
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %q template for notifier %q: %w", kind, notifierName, err)
+	}
+	return buf.String(), nil
+}
+
+// Render the given HTML template kind (e.g. "available.html") for the given notifier, preferring a
+// per-notifier override. Fields interpolated from data are HTML-escaped by html/template.
+func renderHTML(kind, notifierName string, data interface{}) (string, error) {
+	tmpl := templates.forHTMLKind(kind)
+	if override, ok := htmlTemplateOverrides[notifierName+"."+kind]; ok {
+		tmpl = override
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render %q template for notifier %q: %w", kind, notifierName, err)
+	}
+	return buf.String(), nil
+}
+
+// Render the plain-text kind for the given notifier, along with its "<kind>.html" counterpart
+// (e.g. "available.html"), a richer layout meant for notifiers that send a separate HTML
+// alternative alongside the plain-text message, such as the email notifier
+func renderWithHTML(kind, notifierName string, data interface{}) (plain, html string, err error) {
+	if plain, err = render(kind, notifierName, data); err != nil {
+		return "", "", err
+	}
+	if html, err = renderHTML(kind+".html", notifierName, data); err != nil {
+		return "", "", err
+	}
+	return plain, html, nil
+}
+
+// Return the default plain-text template matching the given kind
+func (t Templates) forKind(kind string) *template.Template {
+	switch kind {
+	case "available":
+		return t.available
+	case "test":
+		return t.test
+	case "startup":
+		return t.startup
+	default:
+		return nil
+	}
+}
+
+// Return the default HTML template matching the given kind (e.g. "available.html")
+func (t Templates) forHTMLKind(kind string) *htmltemplate.Template {
+	switch kind {
+	case "available.html":
+		return t.availableHTML
+	case "test.html":
+		return t.testHTML
+	case "startup.html":
+		return t.startupHTML
+	default:
+		return nil
+	}
+}
+
+// Convert a Reward to the representation expected by notification templates
+func (r *Reward) toTemplateReward() TemplateReward {
+	return TemplateReward{
+		ID:             r.id,
+		Title:          r.title,
+		TitleWithPrice: r.title_with_price,
+		Price:          r.price,
+		Available:      r.available,
+		Limit:          r.limit,
+	}
+}